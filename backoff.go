@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultBackoffCap = 5 * time.Minute
+
+var (
+	backoffCap    time.Duration
+	backoffJitter float64
+	noBackoff     bool
+
+	// BackoffFunc computes the delay before the next fetch attempt, given the
+	// number of consecutive failures seen so far and the most recent
+	// response (nil on a transport error). It's a package var, analogous to
+	// the RetryBackoff hook on the ACME client, so tests can swap in
+	// deterministic delays.
+	BackoffFunc = defaultBackoff
+)
+
+// defaultBackoff implements exponential backoff with jitter:
+// min(backoffCap, fetchDelay*2^n) randomized by ±backoffJitter. A
+// Retry-After header on a retryable resp takes precedence over the computed
+// delay, but is floored at fetchDelay so a server-supplied 0 or an
+// already-past HTTP-date can't make the loop busy-spin. n == 0 (no
+// consecutive failures) always returns fetchDelay unmodified, so the steady
+// cadence stays exact rather than being jittered on every successful fetch.
+func defaultBackoff(n int, resp *http.Response) time.Duration {
+	if resp != nil && isRetryable(resp, nil) {
+		if d, ok := retryAfter(resp); ok {
+			if d < fetchDelay {
+				d = fetchDelay
+			}
+			return d
+		}
+	}
+
+	if n <= 0 {
+		return fetchDelay
+	}
+
+	delay := fetchDelay * time.Duration(uint64(1)<<uint(n))
+	if delay <= 0 || delay > backoffCap {
+		delay = backoffCap
+	}
+
+	if backoffJitter > 0 {
+		factor := 1 + (rand.Float64()*2-1)*backoffJitter
+		delay = time.Duration(float64(delay) * factor)
+	}
+
+	return delay
+}
+
+// retryAfter parses a Retry-After header in either delta-seconds or
+// HTTP-date form.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// isRetryable reports whether a fetch outcome should count as a failure for
+// backoff purposes: a transport error, a 5xx, or a 429.
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDefaultBackoffExponential(t *testing.T) {
+	fetchDelay = time.Second
+	backoffCap = time.Minute
+	backoffJitter = 0
+
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{0, 1 * time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := defaultBackoff(tt.n, nil); got != tt.want {
+			t.Errorf("defaultBackoff(%d, nil) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultBackoffNoJitterOnSteadyCadence(t *testing.T) {
+	fetchDelay = 3 * time.Second
+	backoffCap = time.Minute
+	backoffJitter = 0.25
+
+	for i := 0; i < 20; i++ {
+		if got := defaultBackoff(0, &http.Response{StatusCode: http.StatusOK}); got != fetchDelay {
+			t.Fatalf("defaultBackoff(0, 200) = %s, want exact fetchDelay %s (jitter must not apply when nothing is failing)", got, fetchDelay)
+		}
+	}
+}
+
+func TestDefaultBackoffCapsAtBackoffCap(t *testing.T) {
+	fetchDelay = time.Second
+	backoffCap = 10 * time.Second
+	backoffJitter = 0
+
+	if got := defaultBackoff(10, nil); got != backoffCap {
+		t.Errorf("defaultBackoff(10, nil) = %s, want cap %s", got, backoffCap)
+	}
+}
+
+func TestDefaultBackoffIgnoresRetryAfterOnSuccess(t *testing.T) {
+	fetchDelay = time.Second
+	backoffCap = time.Minute
+	backoffJitter = 0
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Retry-After": []string{"30"}}}
+	if got := defaultBackoff(0, resp); got != fetchDelay {
+		t.Errorf("defaultBackoff with 200 resp = %s, want fetchDelay %s (Retry-After must be ignored on success)", got, fetchDelay)
+	}
+}
+
+func TestDefaultBackoffFloorsZeroRetryAfter(t *testing.T) {
+	fetchDelay = 3 * time.Second
+	backoffCap = time.Minute
+	backoffJitter = 0
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"0"}}}
+	if got := defaultBackoff(1, resp); got != fetchDelay {
+		t.Errorf("defaultBackoff with Retry-After: 0 = %s, want floor fetchDelay %s", got, fetchDelay)
+	}
+}
+
+func TestDefaultBackoffFloorsPastHTTPDate(t *testing.T) {
+	fetchDelay = 3 * time.Second
+	backoffCap = time.Minute
+	backoffJitter = 0
+
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": []string{past}}}
+	if got := defaultBackoff(1, resp); got != fetchDelay {
+		t.Errorf("defaultBackoff with past Retry-After date = %s, want floor fetchDelay %s", got, fetchDelay)
+	}
+}
+
+func TestRetryAfterDeltaSeconds(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"120"}}}
+	d, ok := retryAfter(resp)
+	if !ok || d != 120*time.Second {
+		t.Errorf("retryAfter(delta-seconds) = %s, %v, want 120s, true", d, ok)
+	}
+}
+
+func TestRetryAfterHTTPDate(t *testing.T) {
+	future := time.Now().Add(2 * time.Minute).UTC().Format(http.TimeFormat)
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{future}}}
+	d, ok := retryAfter(resp)
+	if !ok {
+		t.Fatal("retryAfter(HTTP-date) ok = false, want true")
+	}
+	if d <= 0 || d > 2*time.Minute+time.Second {
+		t.Errorf("retryAfter(HTTP-date) = %s, want ~2m", d)
+	}
+}
+
+func TestRetryAfterMissing(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	if _, ok := retryAfter(resp); ok {
+		t.Error("retryAfter with no header: ok = true, want false")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	errTest := errors.New("boom")
+
+	tests := []struct {
+		name string
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{"transport error", nil, errTest, true},
+		{"5xx", &http.Response{StatusCode: 503}, nil, true},
+		{"429", &http.Response{StatusCode: 429}, nil, true},
+		{"200", &http.Response{StatusCode: 200}, nil, false},
+		{"404", &http.Response{StatusCode: 404}, nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := isRetryable(tt.resp, tt.err); got != tt.want {
+			t.Errorf("%s: isRetryable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// exitCertCrit is returned when the leaf certificate is inside --cert-crit
+// of expiring (or already expired), so cron/monit can treat this run as a
+// failed TLS-freshness probe.
+const exitCertCrit = 2
+
+var (
+	certWarn time.Duration
+	certCrit time.Duration
+)
+
+// formatExpiry renders a duration the way the status line expects, e.g.
+// "12d3h", "3h4m", "9m2s". Negative durations (an already-expired cert) are
+// rendered with a leading "-".
+func formatExpiry(d time.Duration) string {
+	if d < 0 {
+		return "-" + formatExpiry(-d)
+	}
+
+	days := int64(d.Hours()) / 24
+	hours := int64(d.Hours()) % 24
+	if days > 0 {
+		return fmt.Sprintf("%dd%dh", days, hours)
+	}
+
+	minutes := int64(d.Minutes()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+
+	seconds := int64(d.Seconds()) % 60
+	if minutes > 0 {
+		return fmt.Sprintf("%dm%ds", minutes, seconds)
+	}
+
+	return fmt.Sprintf("%ds", seconds)
+}
+
+// checkCertExpiry prints a WARN/CRIT annotation once the leaf's remaining
+// lifetime crosses --cert-warn/--cert-crit, and returns that remaining
+// lifetime so callers can report it. On CRIT it calls shutdown(exitCertCrit)
+// in its own goroutine rather than inline: shutdown cancels the target's own
+// context and waits on the same WaitGroup this call is running under, so
+// calling it synchronously would deadlock forever waiting for this very
+// goroutine to return. Running it via go lets this goroutine return to
+// Target.run, which sees the cancellation and exits normally.
+func checkCertExpiry(printer *statusPrinter, tag, url string, leaf *x509.Certificate, shutdown func(int)) time.Time {
+	remaining := time.Until(leaf.NotAfter)
+
+	switch {
+	case certCrit > 0 && remaining <= certCrit:
+		printer.printf(tag, "CRIT: certificate for %s expires in %s (threshold %s)",
+			url, formatExpiry(remaining), certCrit)
+		go shutdown(exitCertCrit)
+	case certWarn > 0 && remaining <= certWarn:
+		printer.printf(tag, "WARN: certificate for %s expires in %s (threshold %s)",
+			url, formatExpiry(remaining), certWarn)
+	}
+
+	return leaf.NotAfter
+}
+
+// printChainInfo prints the subject/issuer of every certificate in the
+// chain and the leaf's SANs. It's called once per target, on its first
+// successful fetch, so users can confirm which certificate is being served.
+func printChainInfo(printer *statusPrinter, tag string, chain []*x509.Certificate) {
+	if len(chain) == 0 {
+		return
+	}
+
+	printer.printf(tag, "TLS chain:")
+	for i, cert := range chain {
+		printer.printf(tag, "  [%d] subject=%q issuer=%q", i, cert.Subject, cert.Issuer)
+	}
+
+	leaf := chain[0]
+	if len(leaf.DNSNames) > 0 || len(leaf.IPAddresses) > 0 {
+		printer.printf(tag, "  SANs: dns=%v ip=%v", leaf.DNSNames, leaf.IPAddresses)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+func TestFormatExpiry(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{9 * time.Second, "9s"},
+		{3*time.Minute + 4*time.Second, "3m4s"},
+		{3*time.Hour + 4*time.Minute, "3h4m"},
+		{12*24*time.Hour + 3*time.Hour, "12d3h"},
+		{-5 * time.Second, "-5s"},
+	}
+
+	for _, tt := range tests {
+		if got := formatExpiry(tt.d); got != tt.want {
+			t.Errorf("formatExpiry(%s) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCertExpiryWarnDoesNotShutdown(t *testing.T) {
+	certWarn = time.Hour
+	certCrit = 0
+	defer func() { certWarn, certCrit = 0, 0 }()
+
+	printer := newStatusPrinter()
+	defer printer.close()
+
+	shutdownCalled := false
+	leaf := &x509.Certificate{NotAfter: time.Now().Add(30 * time.Minute)}
+
+	notAfter := checkCertExpiry(printer, "t0", "https://example.com", leaf, func(int) { shutdownCalled = true })
+
+	if !notAfter.Equal(leaf.NotAfter) {
+		t.Errorf("checkCertExpiry returned %v, want %v", notAfter, leaf.NotAfter)
+	}
+	if shutdownCalled {
+		t.Error("checkCertExpiry called shutdown on a WARN-only threshold")
+	}
+}
+
+func TestCheckCertExpiryCritCallsShutdown(t *testing.T) {
+	certWarn = 0
+	certCrit = time.Hour
+	defer func() { certWarn, certCrit = 0, 0 }()
+
+	printer := newStatusPrinter()
+	defer printer.close()
+
+	var gotCode int
+	called := make(chan struct{})
+	leaf := &x509.Certificate{NotAfter: time.Now().Add(-time.Minute)}
+
+	// shutdown is invoked via `go shutdown(...)` (see checkCertExpiry), not
+	// inline, so it must be observed asynchronously here too.
+	checkCertExpiry(printer, "t0", "https://example.com", leaf, func(code int) {
+		gotCode = code
+		close(called)
+	})
+
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("checkCertExpiry did not call shutdown for an already-expired cert within --cert-crit")
+	}
+	if gotCode != exitCertCrit {
+		t.Errorf("shutdown called with code %d, want %d", gotCode, exitCertCrit)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// pollInterval is how often CertificateReloader checks the watched file's
+// mtime when fsnotify isn't available (e.g. no inotify support).
+const pollInterval = 5 * time.Second
+
+// CertificateReloader watches a PEM file on disk and keeps an atomically
+// swappable *x509.CertPool in sync with its contents. Callers should hold on
+// to the reloader for the lifetime of the process and call Pool() on every
+// use rather than caching the result themselves.
+// certReloadTag is the statusPrinter tag used for cert-reload log lines, so
+// they interleave in order with per-target fetch output instead of racing
+// straight to stdout.
+const certReloadTag = "cert"
+
+type CertificateReloader struct {
+	path    string
+	base    *x509.CertPool
+	printer *statusPrinter
+
+	pool    atomic.Pointer[x509.CertPool]
+	reloads atomic.Int64
+}
+
+// NewCertificateReloader loads path once, appends it onto a clone of base,
+// and starts a background watcher that reloads the pool whenever the file
+// changes. base is typically the system cert pool; it is never mutated.
+// Reload/error messages are routed through printer so they stay serialized
+// with the rest of the program's output. It returns an error only if the
+// initial load fails.
+func NewCertificateReloader(path string, base *x509.CertPool, printer *statusPrinter) (*CertificateReloader, error) {
+	r := &CertificateReloader{path: path, base: base, printer: printer}
+
+	pool, err := r.loadPool()
+	if err != nil {
+		return nil, err
+	}
+	r.pool.Store(pool)
+
+	go r.watch()
+
+	return r, nil
+}
+
+// Pool returns the most recently loaded certificate pool.
+func (r *CertificateReloader) Pool() *x509.CertPool {
+	return r.pool.Load()
+}
+
+// Reloads returns the number of times the pool has been successfully swapped
+// since startup.
+func (r *CertificateReloader) Reloads() int64 {
+	return r.reloads.Load()
+}
+
+func (r *CertificateReloader) loadPool() (*x509.CertPool, error) {
+	certs, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("read cert file: %w", err)
+	}
+
+	pool := r.base.Clone()
+	if ok := pool.AppendCertsFromPEM(certs); !ok {
+		return nil, fmt.Errorf("no certs found in %s", r.path)
+	}
+
+	return pool, nil
+}
+
+func (r *CertificateReloader) watch() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		r.printer.printf(certReloadTag, "cert watcher: falling back to polling every %s: %v", pollInterval, err)
+		r.pollForever()
+		return
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(r.path)); err != nil {
+		r.printer.printf(certReloadTag, "cert watcher: falling back to polling every %s: %v", pollInterval, err)
+		r.pollForever()
+		return
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(r.path) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			r.reload()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			r.printer.printf(certReloadTag, "cert watcher error: %v", err)
+		}
+	}
+}
+
+// pollForever checks the watched file's mtime on a fixed interval. It's used
+// when fsnotify can't watch the filesystem.
+func (r *CertificateReloader) pollForever() {
+	var lastMod time.Time
+	if info, err := os.Stat(r.path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		info, err := os.Stat(r.path)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(lastMod) {
+			lastMod = info.ModTime()
+			r.reload()
+		}
+	}
+}
+
+func (r *CertificateReloader) reload() {
+	pool, err := r.loadPool()
+	if err != nil {
+		r.printer.printf(certReloadTag, "cert reload failed, keeping previous pool: %v", err)
+		return
+	}
+
+	r.pool.Store(pool)
+	n := r.reloads.Add(1)
+	r.printer.printf(certReloadTag, "cert reload: picked up changes to %s (reload #%d)", r.path, n)
+}
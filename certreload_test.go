@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// selfSignedPEM returns a freshly generated self-signed certificate, PEM
+// encoded, so tests don't depend on a fixture that might expire.
+func selfSignedPEM(t *testing.T, commonName string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestNewCertificateReloaderLoadsInitialPool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, selfSignedPEM(t, "initial"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	printer := newStatusPrinter()
+	defer printer.close()
+
+	r, err := NewCertificateReloader(path, x509.NewCertPool(), printer)
+	if err != nil {
+		t.Fatalf("NewCertificateReloader: %v", err)
+	}
+	if r.Pool() == nil {
+		t.Error("Pool() = nil after successful load")
+	}
+	if got := r.Reloads(); got != 0 {
+		t.Errorf("Reloads() = %d, want 0 before any reload", got)
+	}
+}
+
+func TestNewCertificateReloaderRejectsInvalidPEM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	printer := newStatusPrinter()
+	defer printer.close()
+
+	if _, err := NewCertificateReloader(path, x509.NewCertPool(), printer); err == nil {
+		t.Error("NewCertificateReloader with invalid PEM: err = nil, want error")
+	}
+}
+
+func TestCertificateReloaderReloadSwapsPool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, selfSignedPEM(t, "initial"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	printer := newStatusPrinter()
+	defer printer.close()
+
+	r, err := NewCertificateReloader(path, x509.NewCertPool(), printer)
+	if err != nil {
+		t.Fatalf("NewCertificateReloader: %v", err)
+	}
+	before := r.Pool()
+
+	if err := os.WriteFile(path, selfSignedPEM(t, "rotated"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	r.reload()
+
+	if got := r.Reloads(); got != 1 {
+		t.Errorf("Reloads() = %d, want 1 after one successful reload", got)
+	}
+	if r.Pool() == before {
+		t.Error("Pool() unchanged after a successful reload")
+	}
+}
+
+func TestCertificateReloaderReloadKeepsPoolOnInvalidPEM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, selfSignedPEM(t, "initial"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	printer := newStatusPrinter()
+	defer printer.close()
+
+	r, err := NewCertificateReloader(path, x509.NewCertPool(), printer)
+	if err != nil {
+		t.Fatalf("NewCertificateReloader: %v", err)
+	}
+	before := r.Pool()
+
+	if err := os.WriteFile(path, []byte("garbage"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	r.reload()
+
+	if got := r.Reloads(); got != 0 {
+		t.Errorf("Reloads() = %d, want 0 after a failed reload", got)
+	}
+	if r.Pool() != before {
+		t.Error("Pool() changed after a failed reload, want previous pool kept live")
+	}
+}
@@ -1,13 +1,14 @@
 package main
 
 import (
+	"bufio"
 	"context"
-	"crypto/tls"
 	"crypto/x509"
 	"flag"
 	"fmt"
-	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,12 +17,56 @@ var (
 	exitCount            int
 	expectedTimeDuration time.Duration
 	startTime            time.Time
-	successCount         int
-	url                  string
+	urls                 stringSliceFlag
+	urlsFile             string
+	exitMode             string
 	forever              bool
 	certFile             string
+
+	certReloader *CertificateReloader
 )
 
+// stringSliceFlag collects repeated occurrences of a flag, e.g.
+// --url a --url b --url c.
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *stringSliceFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// readURLsFile reads one URL per line, skipping blank lines.
+func readURLsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		urls = append(urls, line)
+	}
+
+	return urls, scanner.Err()
+}
+
+// targetTag returns a short prefix for status lines. A single target keeps
+// the tag simple; multiple targets are numbered t0, t1, ...
+func targetTag(i, total int) string {
+	if total == 1 {
+		return "t0"
+	}
+	return fmt.Sprintf("t%d", i)
+}
+
 func elapsedTime() string {
 	duration := time.Since(startTime)
 
@@ -68,98 +113,148 @@ func remainingTime() string {
 	return formatDuration(remaining, suffix)
 }
 
-func fetch(url string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		fmt.Printf("%s (%s) Error creating request: %v\n", elapsedTime(), remainingTime(), err)
-		return
+// certStatus returns a short suffix reporting how many times the pinned CA
+// pool has been hot-reloaded, or "" when --cert isn't in use.
+func certStatus() string {
+	if certReloader == nil {
+		return ""
 	}
+	return fmt.Sprintf(", cert reloads: %d", certReloader.Reloads())
+}
 
-	rootCAs, _ := x509.SystemCertPool()
-	if rootCAs == nil {
-		rootCAs = x509.NewCertPool()
-	}
+// collectURLs merges --url (repeatable) and --urls-file into one list,
+// erroring out if neither produced anything.
+func collectURLs() []string {
+	all := append([]string{}, urls...)
 
-	if certFile != "" {
-		// Read in the cert file
-		certs, err := os.ReadFile(certFile)
+	if urlsFile != "" {
+		fromFile, err := readURLsFile(urlsFile)
 		if err != nil {
-			fmt.Printf("%s (%s) Failed to read cert file: %v\n", elapsedTime(), remainingTime(), err)
-			os.Exit(1) // Exiting the program when reading the certificate file fails
-		}
-
-		// Append our cert to the system pool
-		if ok := rootCAs.AppendCertsFromPEM(certs); !ok {
-			fmt.Printf("%s (%s) No certs appended, using system certs only\n", elapsedTime(), remainingTime())
+			fmt.Printf("Failed to read --urls-file: %v\n", err)
+			os.Exit(1)
 		}
+		all = append(all, fromFile...)
 	}
 
-	var untrustedCertError error
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				RootCAs: rootCAs,
-				VerifyConnection: func(cs tls.ConnectionState) error {
-					opts := x509.VerifyOptions{
-						DNSName: cs.ServerName,
-						Roots:   rootCAs,
-					}
-
-					if _, err := cs.PeerCertificates[0].Verify(opts); err != nil {
-						untrustedCertError = fmt.Errorf("untrusted SSL certificate: %v", err)
-					}
-
-					return nil
-				},
-			},
-		},
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("%s (%s) Error: %v\n", elapsedTime(), remainingTime(), err)
-		return
+	if len(all) == 0 {
+		flag.Usage()
+		os.Exit(1)
 	}
-	defer resp.Body.Close()
 
-	if untrustedCertError != nil {
-		fmt.Printf("%s (%s) HTTP Response Code: %d, %v for %s\n", elapsedTime(), remainingTime(), resp.StatusCode, untrustedCertError, url)
-	} else {
-		fmt.Printf("%s (%s) HTTP Response Code: %d for %s\n", elapsedTime(), remainingTime(), resp.StatusCode, url)
-	}
+	return all
+}
 
-	if resp.StatusCode == http.StatusOK {
-		successCount++
-		if !forever && successCount == exitCount {
-			fmt.Printf("Exiting after %d successful fetches.\n", exitCount)
-			os.Exit(0)
-		}
+// exitSatisfied reports whether reachedCount targets (out of total) having
+// hit --count satisfies --exit-mode.
+func exitSatisfied(mode string, reachedCount, total int) bool {
+	switch mode {
+	case "all":
+		return reachedCount >= total
+	case "majority":
+		return reachedCount*2 > total
+	default: // "any"
+		return reachedCount >= 1
 	}
 }
 
 func main() {
-	flag.StringVar(&url, "url", "", "URL to fetch")
+	flag.Var(&urls, "url", "URL to fetch (repeatable)")
+	flag.StringVar(&urlsFile, "urls-file", "", "Path to a newline-delimited file of URLs to fetch")
 	flag.DurationVar(&expectedTimeDuration, "predicted", 10*time.Minute, "Expected time for fetching the URL")
 	flag.DurationVar(&fetchDelay, "delay", 3*time.Second, "Delay between fetch attempts")
 	flag.IntVar(&exitCount, "count", 5, "Number of successful fetches before program exit")
 	flag.BoolVar(&forever, "forever", false, "Keep running indefinitely even after meeting success count")
 	flag.StringVar(&certFile, "cert", "", "Path to additional cert file")
+	flag.DurationVar(&backoffCap, "backoff-cap", defaultBackoffCap, "Maximum delay between fetch attempts after repeated failures")
+	flag.Float64Var(&backoffJitter, "backoff-jitter", 0.25, "Fractional jitter applied to the backoff delay, e.g. 0.25 for ±25%")
+	flag.BoolVar(&noBackoff, "no-backoff", false, "Disable backoff and always wait exactly --delay between attempts")
+	flag.DurationVar(&certWarn, "cert-warn", 0, "Print a WARN annotation once the leaf certificate expires within this long (0 disables)")
+	flag.DurationVar(&certCrit, "cert-crit", 0, "Exit non-zero once the leaf certificate expires within this long, or is already expired (0 disables)")
+	flag.StringVar(&exitMode, "exit-mode", "any", "When to exit with multiple targets: any, all, or majority reach --count")
+	flag.StringVar(&outputMode, "output", "text", "Output format: text, json, or prom")
+	flag.StringVar(&promFile, "prom-file", "", "Path to a Prometheus textfile-collector file to write (required for --output prom)")
 	flag.Parse()
 
-	if url == "" {
-		flag.Usage()
+	targetURLs := collectURLs()
+
+	switch exitMode {
+	case "any", "all", "majority":
+	default:
+		fmt.Printf("Invalid --exit-mode %q: must be any, all, or majority\n", exitMode)
+		os.Exit(1)
+	}
+
+	switch outputMode {
+	case "text", "json":
+	case "prom":
+		if promFile == "" {
+			fmt.Println("--output prom requires --prom-file")
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Invalid --output %q: must be text, json, or prom\n", outputMode)
 		os.Exit(1)
 	}
 
 	startTime = time.Now()
 
-	ticker := time.NewTicker(fetchDelay)
-	defer ticker.Stop()
+	printer := newStatusPrinter()
+	reached := make(chan *Target)
+
+	if certFile != "" {
+		base, _ := x509.SystemCertPool()
+		if base == nil {
+			base = x509.NewCertPool()
+		}
 
-	for range ticker.C {
-		fetch(url)
+		reloader, err := NewCertificateReloader(certFile, base, printer)
+		if err != nil {
+			fmt.Printf("Failed to read cert file: %v\n", err)
+			os.Exit(1)
+		}
+		certReloader = reloader
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	var shutdownOnce sync.Once
+
+	// shutdown stops every target's goroutine and waits for it to return
+	// before tearing down the shared printer, so no target can send on
+	// printer.lines after it's been closed. sync.Once makes it safe to call
+	// from multiple goroutines (e.g. two targets hitting --cert-crit at
+	// once) without double-closing anything.
+	shutdown := func(code int) {
+		shutdownOnce.Do(func() {
+			cancel()
+			wg.Wait()
+			printer.close()
+			os.Exit(code)
+		})
+	}
+
+	targets := make([]*Target, len(targetURLs))
+	for i, u := range targetURLs {
+		targets[i] = newTarget(u, targetTag(i, len(targetURLs)))
+		wg.Add(1)
+		go func(t *Target) {
+			defer wg.Done()
+			t.run(ctx, printer, reached, shutdown)
+		}(targets[i])
+	}
+
+	if forever {
+		select {}
+	}
+
+	reachedCount := 0
+	for t := range reached {
+		reachedCount++
+		printer.printf(t.tag, "reached %d successful fetches (%d/%d targets satisfied, exit-mode=%s)",
+			exitCount, reachedCount, len(targets), exitMode)
+
+		if exitSatisfied(exitMode, reachedCount, len(targets)) {
+			shutdown(0)
+		}
 	}
 }
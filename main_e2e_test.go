@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// generateServerCert returns a self-signed, CA:true cert/key pair for
+// 127.0.0.1 expiring at notAfter, suitable both as an httptest TLS server's
+// leaf cert and (via --cert) as the client's sole trusted root.
+func generateServerCert(t *testing.T, notAfter time.Time) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM
+}
+
+// TestCertCritExitsWithoutHanging is an end-to-end regression test for the
+// self-deadlock in the chunk0-4 review: checkCertExpiry used to call the
+// wg-tracked shutdown closure synchronously from inside a target's own
+// goroutine, so wg.Wait() blocked forever on a goroutine that could never
+// return. It builds the real binary, points it at a server presenting a
+// near-expired certificate, and asserts the process exits with
+// exitCertCrit promptly instead of hanging.
+func TestCertCritExitsWithoutHanging(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and execs the real binary; skipped in -short")
+	}
+
+	certPEM, keyPEM := generateServerCert(t, time.Now().Add(90*time.Second))
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("X509KeyPair: %v", err)
+	}
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, certPEM, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	binPath := filepath.Join(dir, "cloudelf_e2e")
+	if out, err := exec.Command("go", "build", "-o", binPath, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, binPath,
+		"--url", srv.URL,
+		"--cert", caFile,
+		"--cert-crit", "1h",
+		// Set high enough that --count is never satisfied, so the only
+		// thing that can trigger the exit is the CRIT shutdown path under
+		// test (not a race against the --exit-mode success path).
+		"--count", "1000000",
+		"--delay", "10ms",
+		"--predicted", "5s",
+	)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	start := time.Now()
+	runErr := cmd.Run()
+	elapsed := time.Since(start)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		t.Fatalf("cloudelf hung instead of exiting on --cert-crit; output:\n%s", out.String())
+	}
+
+	exitErr, ok := runErr.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("cmd.Run() err = %v (%T), want *exec.ExitError; output:\n%s", runErr, runErr, out.String())
+	}
+	if got := exitErr.ExitCode(); got != exitCertCrit {
+		t.Errorf("exit code = %d, want %d; output:\n%s", got, exitCertCrit, out.String())
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("cloudelf took %s to exit, want well under --predicted; likely hanging", elapsed)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExitSatisfied(t *testing.T) {
+	tests := []struct {
+		mode         string
+		reachedCount int
+		total        int
+		want         bool
+	}{
+		{"any", 0, 3, false},
+		{"any", 1, 3, true},
+		{"all", 2, 3, false},
+		{"all", 3, 3, true},
+		{"majority", 1, 3, false},
+		{"majority", 2, 3, true},
+		{"majority", 2, 4, false},
+		{"majority", 3, 4, true},
+		{"any", 1, 1, true},
+		{"all", 1, 1, true},
+	}
+
+	for _, tt := range tests {
+		if got := exitSatisfied(tt.mode, tt.reachedCount, tt.total); got != tt.want {
+			t.Errorf("exitSatisfied(%q, %d, %d) = %v, want %v", tt.mode, tt.reachedCount, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestTargetTag(t *testing.T) {
+	tests := []struct {
+		i, total int
+		want     string
+	}{
+		{0, 1, "t0"},
+		{0, 2, "t0"},
+		{1, 2, "t1"},
+		{2, 3, "t2"},
+	}
+
+	for _, tt := range tests {
+		if got := targetTag(tt.i, tt.total); got != tt.want {
+			t.Errorf("targetTag(%d, %d) = %q, want %q", tt.i, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestCollectURLsFromFlags(t *testing.T) {
+	origURLs, origFile := urls, urlsFile
+	defer func() { urls, urlsFile = origURLs, origFile }()
+
+	urls = stringSliceFlag{"http://a.example", "http://b.example"}
+	urlsFile = ""
+
+	got := collectURLs()
+	want := []string{"http://a.example", "http://b.example"}
+	if len(got) != len(want) {
+		t.Fatalf("collectURLs() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("collectURLs()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCollectURLsMergesFile(t *testing.T) {
+	origURLs, origFile := urls, urlsFile
+	defer func() { urls, urlsFile = origURLs, origFile }()
+
+	f := t.TempDir() + "/urls.txt"
+	contents := strings.Join([]string{"http://c.example", "", "http://d.example"}, "\n")
+	if err := os.WriteFile(f, []byte(contents), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	urls = stringSliceFlag{"http://a.example"}
+	urlsFile = f
+
+	got := collectURLs()
+	want := []string{"http://a.example", "http://c.example", "http://d.example"}
+	if len(got) != len(want) {
+		t.Fatalf("collectURLs() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("collectURLs()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
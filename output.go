@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	outputMode string
+	promFile   string
+
+	promMu      sync.Mutex
+	promResults = map[string]*FetchResult{}
+)
+
+// FetchResult captures everything worth knowing about one fetch attempt, in
+// a form that renders equally well as a text line, a JSON object, or a set
+// of Prometheus gauges.
+type FetchResult struct {
+	Timestamp           time.Time  `json:"ts"`
+	URL                 string     `json:"url"`
+	StatusCode          int        `json:"status_code"`
+	LatencyMS           int64      `json:"latency_ms"`
+	Err                 string     `json:"err,omitempty"`
+	CertNotAfter        *time.Time `json:"cert_not_after,omitempty"`
+	CertUntrusted       bool       `json:"cert_untrusted"`
+	Attempt             int64      `json:"attempt"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Elapsed             string     `json:"elapsed"`
+	Remaining           string     `json:"remaining"`
+	SuccessCount        int64      `json:"-"`
+}
+
+// reportResult renders a FetchResult according to --output: a human-readable
+// line for "text" (the default), a JSON object per line for "json", or an
+// update to the Prometheus textfile for "prom".
+func reportResult(printer *statusPrinter, tag string, r FetchResult) {
+	switch outputMode {
+	case "json":
+		b, err := json.Marshal(r)
+		if err != nil {
+			printer.printf(tag, "failed to marshal JSON result: %v", err)
+			return
+		}
+		printer.raw(string(b))
+	case "prom":
+		updatePromFile(r)
+	default:
+		printer.printf(tag, "%s", formatText(r))
+	}
+}
+
+func formatText(r FetchResult) string {
+	line := fmt.Sprintf("%s (%s) ", r.Elapsed, r.Remaining)
+
+	if r.Err != "" {
+		return line + fmt.Sprintf("Error: %s", r.Err)
+	}
+
+	line += fmt.Sprintf("HTTP Response Code: %d for %s", r.StatusCode, r.URL)
+
+	if r.CertUntrusted {
+		line += ", untrusted SSL certificate"
+	}
+	if r.CertNotAfter != nil {
+		line += fmt.Sprintf(", cert expires in %s", formatExpiry(time.Until(*r.CertNotAfter)))
+	}
+	line += certStatus()
+
+	return line
+}
+
+// updatePromFile records r under its target and rewrites --prom-file with
+// gauges for every target seen so far, so a textfile collector always has a
+// complete, self-consistent snapshot.
+func updatePromFile(r FetchResult) {
+	promMu.Lock()
+	defer promMu.Unlock()
+
+	promResults[r.URL] = &r
+
+	f, err := os.Create(promFile)
+	if err != nil {
+		fmt.Printf("failed to write --prom-file: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	for u, res := range promResults {
+		fmt.Fprintf(f, "cloudelf_last_status_code{url=%q} %d\n", u, res.StatusCode)
+		fmt.Fprintf(f, "cloudelf_consecutive_failures{url=%q} %d\n", u, res.ConsecutiveFailures)
+		fmt.Fprintf(f, "cloudelf_success_total{url=%q} %d\n", u, res.SuccessCount)
+		if res.CertNotAfter != nil {
+			fmt.Fprintf(f, "cloudelf_cert_expiry_seconds{url=%q} %d\n", u, int64(time.Until(*res.CertNotAfter).Seconds()))
+		}
+	}
+}
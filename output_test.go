@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatTextSuccess(t *testing.T) {
+	r := FetchResult{Elapsed: "3s", Remaining: "7s", StatusCode: 200, URL: "http://example.com"}
+	got := formatText(r)
+	want := "3s (7s) HTTP Response Code: 200 for http://example.com"
+	if got != want {
+		t.Errorf("formatText(success) = %q, want %q", got, want)
+	}
+}
+
+func TestFormatTextError(t *testing.T) {
+	r := FetchResult{Elapsed: "3s", Remaining: "7s", Err: "boom"}
+	got := formatText(r)
+	want := "3s (7s) Error: boom"
+	if got != want {
+		t.Errorf("formatText(error) = %q, want %q", got, want)
+	}
+}
+
+func TestFormatTextCertAnnotations(t *testing.T) {
+	notAfter := time.Now().Add(2 * time.Hour)
+	r := FetchResult{
+		Elapsed: "1s", Remaining: "9s", StatusCode: 200, URL: "https://example.com",
+		CertUntrusted: true, CertNotAfter: &notAfter,
+	}
+	got := formatText(r)
+	if !strings.Contains(got, "untrusted SSL certificate") {
+		t.Errorf("formatText(cert untrusted) = %q, want it to mention untrusted SSL certificate", got)
+	}
+	if !strings.Contains(got, "cert expires in 1h59m") {
+		t.Errorf("formatText(cert expiry) = %q, want it to mention cert expiry", got)
+	}
+}
+
+func TestFetchResultJSONOmitsEmptyFields(t *testing.T) {
+	r := FetchResult{Elapsed: "3s", Remaining: "7s", URL: "http://example.com", StatusCode: 200}
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	for _, field := range []string{"err", "cert_not_after"} {
+		if _, ok := m[field]; ok {
+			t.Errorf("JSON has %q, want it omitted when unset", field)
+		}
+	}
+	for _, field := range []string{"ts", "url", "status_code", "latency_ms", "cert_untrusted", "attempt", "consecutive_failures", "elapsed", "remaining"} {
+		if _, ok := m[field]; !ok {
+			t.Errorf("JSON missing %q", field)
+		}
+	}
+	if _, ok := m["success_count"]; ok {
+		t.Error("JSON has success_count, want it excluded (json:\"-\")")
+	}
+}
+
+func TestUpdatePromFile(t *testing.T) {
+	promMu.Lock()
+	promResults = map[string]*FetchResult{}
+	promMu.Unlock()
+
+	dir := t.TempDir()
+	promFile = filepath.Join(dir, "cloudelf.prom")
+
+	notAfter := time.Now().Add(30 * time.Minute)
+	updatePromFile(FetchResult{
+		URL: "http://example.com", StatusCode: 200, ConsecutiveFailures: 0,
+		SuccessCount: 3, CertNotAfter: &notAfter,
+	})
+
+	b, err := os.ReadFile(promFile)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	out := string(b)
+
+	for _, want := range []string{
+		`cloudelf_last_status_code{url="http://example.com"} 200`,
+		`cloudelf_consecutive_failures{url="http://example.com"} 0`,
+		`cloudelf_success_total{url="http://example.com"} 3`,
+		`cloudelf_cert_expiry_seconds{url="http://example.com"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("prom file missing %q, got:\n%s", want, out)
+		}
+	}
+}
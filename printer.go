@@ -0,0 +1,45 @@
+package main
+
+import "fmt"
+
+// statusPrinter serializes status lines from multiple target goroutines so
+// concurrent fetches don't interleave mid-line on stdout.
+type statusPrinter struct {
+	lines chan string
+	done  chan struct{}
+}
+
+func newStatusPrinter() *statusPrinter {
+	p := &statusPrinter{
+		lines: make(chan string, 64),
+		done:  make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *statusPrinter) run() {
+	for line := range p.lines {
+		fmt.Println(line)
+	}
+	close(p.done)
+}
+
+// printf formats a status line prefixed with the target's tag and queues it
+// for printing.
+func (p *statusPrinter) printf(tag, format string, args ...any) {
+	p.lines <- fmt.Sprintf("[%s] %s", tag, fmt.Sprintf(format, args...))
+}
+
+// raw queues a line for printing as-is, with no tag prefix. Used for
+// machine-readable output modes (e.g. JSON) where the tag would break
+// parsing.
+func (p *statusPrinter) raw(line string) {
+	p.lines <- line
+}
+
+// close drains any buffered lines and blocks until they've been printed.
+func (p *statusPrinter) close() {
+	close(p.lines)
+	<-p.done
+}
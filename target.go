@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Target owns the state needed to poll a single URL: its own success count,
+// backoff state, and last-seen certificate info. These used to be
+// package-level vars, which meant only one URL could ever be polled at a
+// time.
+type Target struct {
+	url string
+	tag string
+
+	successCount        int64
+	attempts            int64
+	consecutiveFailures int
+	printedChainInfo    bool
+}
+
+func newTarget(url, tag string) *Target {
+	return &Target{url: url, tag: tag}
+}
+
+// run drives the fetch loop for this target. If !forever and the target
+// reaches exitCount successes, it reports itself on reached (once) and keeps
+// polling; main decides, based on --exit-mode, when to actually stop the
+// process. run returns as soon as ctx is cancelled, so main can wait for
+// every target to quit before it tears down the shared statusPrinter.
+func (t *Target) run(ctx context.Context, printer *statusPrinter, reached chan<- *Target, shutdown func(int)) {
+	reachedSent := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		resp, err := t.fetch(printer, shutdown)
+
+		if !forever && !reachedSent && atomic.LoadInt64(&t.successCount) >= int64(exitCount) {
+			reachedSent = true
+			select {
+			case reached <- t:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		delay := fetchDelay
+		if !noBackoff {
+			if isRetryable(resp, err) {
+				t.consecutiveFailures++
+			} else {
+				t.consecutiveFailures = 0
+			}
+			delay = BackoffFunc(t.consecutiveFailures, resp)
+			printer.printf(t.tag, "%s (%s) next attempt in %s", elapsedTime(), remainingTime(), delay)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *Target) fetch(printer *statusPrinter, shutdown func(int)) (*http.Response, error) {
+	start := time.Now()
+	attempt := atomic.AddInt64(&t.attempts, 1)
+
+	base := func(err error) FetchResult {
+		r := FetchResult{
+			Timestamp:           start,
+			URL:                 t.url,
+			Attempt:             attempt,
+			ConsecutiveFailures: t.consecutiveFailures,
+			Elapsed:             elapsedTime(),
+			Remaining:           remainingTime(),
+			SuccessCount:        atomic.LoadInt64(&t.successCount),
+		}
+		if err != nil {
+			r.Err = err.Error()
+		}
+		return r
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url, nil)
+	if err != nil {
+		reportResult(printer, t.tag, base(err))
+		return nil, err
+	}
+
+	rootCAs, _ := x509.SystemCertPool()
+	if rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+
+	if certReloader != nil {
+		rootCAs = certReloader.Pool()
+	}
+
+	var untrustedCertError error
+	var peerCerts []*x509.Certificate
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs: rootCAs,
+				VerifyConnection: func(cs tls.ConnectionState) error {
+					opts := x509.VerifyOptions{
+						DNSName: cs.ServerName,
+						Roots:   rootCAs,
+					}
+
+					if _, err := cs.PeerCertificates[0].Verify(opts); err != nil {
+						untrustedCertError = fmt.Errorf("untrusted SSL certificate: %v", err)
+					}
+
+					peerCerts = cs.PeerCertificates
+
+					return nil
+				},
+			},
+		},
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		reportResult(printer, t.tag, base(err))
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var certNotAfter *time.Time
+	if len(peerCerts) > 0 {
+		notAfter := checkCertExpiry(printer, t.tag, t.url, peerCerts[0], shutdown)
+		certNotAfter = &notAfter
+	}
+
+	if resp.StatusCode == http.StatusOK && !t.printedChainInfo {
+		t.printedChainInfo = true
+		printChainInfo(printer, t.tag, peerCerts)
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		atomic.AddInt64(&t.successCount, 1)
+	}
+
+	result := base(nil)
+	result.StatusCode = resp.StatusCode
+	result.LatencyMS = time.Since(start).Milliseconds()
+	result.CertNotAfter = certNotAfter
+	result.CertUntrusted = untrustedCertError != nil
+	result.SuccessCount = atomic.LoadInt64(&t.successCount)
+	reportResult(printer, t.tag, result)
+
+	return resp, nil
+}